@@ -0,0 +1,41 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	adifFieldPattern = regexp.MustCompile(`<(\w+):(\d+)(?::\w+)?>`)
+	adifEorPattern   = regexp.MustCompile(`(?i)<eor>`)
+	adifEohPattern   = regexp.MustCompile(`(?i)<eoh>`)
+)
+
+// splitADIFRecords splits raw ADIF text into per-QSO records, discarding
+// the header (everything up to and including <EOH>, if present).
+func splitADIFRecords(data string) []string {
+	if loc := adifEohPattern.FindStringIndex(data); loc != nil {
+		data = data[loc[1]:]
+	}
+	return adifEorPattern.Split(data, -1)
+}
+
+// parseADIFRecord extracts ADIF fields from a single record into a
+// lower-cased field-name map, e.g. fields["call"], fields["band"].
+func parseADIFRecord(rec string) map[string]string {
+	fields := map[string]string{}
+	for _, m := range adifFieldPattern.FindAllStringSubmatchIndex(rec, -1) {
+		name := strings.ToLower(rec[m[2]:m[3]])
+		length, err := strconv.Atoi(rec[m[4]:m[5]])
+		if err != nil {
+			continue
+		}
+		start := m[1]
+		if start+length > len(rec) {
+			continue
+		}
+		fields[name] = rec[start : start+length]
+	}
+	return fields
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ARClusterAdapter speaks the AR-Cluster telnet protocol. Spot lines
+// use the same "DX de" format as DXSpider; only the login banner and
+// filter syntax differ.
+type ARClusterAdapter struct {
+	loginByCallsign
+}
+
+func NewARClusterAdapter(callsign string) *ARClusterAdapter {
+	return &ARClusterAdapter{loginByCallsign{callsign}}
+}
+
+func (a *ARClusterAdapter) ParseLine(line string) (Spot, bool) {
+	return parseDXLine(line)
+}
+
+// SendFilter issues an AR-Cluster SET/FILTER command built from spec.
+func (a *ARClusterAdapter) SendFilter(w io.Writer, spec FilterSpec) error {
+	var parts []string
+	if len(spec.Bands) > 0 {
+		parts = append(parts, "BAND "+strings.Join(spec.Bands, ","))
+	}
+	if len(spec.Modes) > 0 {
+		parts = append(parts, "MODE "+strings.Join(spec.Modes, ","))
+	}
+	if len(spec.Spotters) > 0 {
+		parts = append(parts, "CONT "+strings.Join(spec.Spotters, ","))
+	}
+	if spec.MinSNR > 0 {
+		parts = append(parts, fmt.Sprintf("SNR %d", spec.MinSNR))
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "SET/FILTER %s\n", strings.Join(parts, " "))
+	return err
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CCClusterAdapter speaks the CC-Cluster telnet protocol. Spot lines
+// use the same "DX de" format as DXSpider; only the login banner and
+// filter syntax differ.
+type CCClusterAdapter struct {
+	loginByCallsign
+}
+
+func NewCCClusterAdapter(callsign string) *CCClusterAdapter {
+	return &CCClusterAdapter{loginByCallsign{callsign}}
+}
+
+func (a *CCClusterAdapter) ParseLine(line string) (Spot, bool) {
+	return parseDXLine(line)
+}
+
+// SendFilter issues a CC-Cluster "SET/DX FILTER" command built from spec.
+func (a *CCClusterAdapter) SendFilter(w io.Writer, spec FilterSpec) error {
+	var parts []string
+	if len(spec.Bands) > 0 {
+		parts = append(parts, "BANDS "+strings.Join(spec.Bands, ","))
+	}
+	if len(spec.Modes) > 0 {
+		parts = append(parts, "MODES "+strings.Join(spec.Modes, ","))
+	}
+	if len(spec.Spotters) > 0 {
+		parts = append(parts, "ORIGIN "+strings.Join(spec.Spotters, ","))
+	}
+	if spec.MinSNR > 0 {
+		parts = append(parts, fmt.Sprintf("MINSNR %d", spec.MinSNR))
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "SET/DX FILTER %s\n", strings.Join(parts, " "))
+	return err
+}
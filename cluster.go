@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Spot is a single DX spot parsed from a cluster feed, independent of
+// which cluster software produced it.
+type Spot struct {
+	Spotter string
+	FreqKhz float64
+	DXCall  string
+	Comment string
+	TimeStr string // as received, e.g. "2301Z"
+	SNR     int    // dB; zero if the adapter doesn't report it (RBN only)
+	WPM     int    // CW speed; zero if the adapter doesn't report it (RBN only)
+}
+
+// FilterSpec is a cluster-agnostic description of which spots to
+// receive, parsed from the -filter flag.
+type FilterSpec struct {
+	Bands    []string
+	Modes    []string
+	Spotters []string
+	MinSNR   int
+}
+
+// ParseFilterSpec parses a spec like "band=20m,15m mode=CW,FT8 snr>10
+// spotter=NA/EU" into a FilterSpec. Unknown tokens are ignored so
+// adapters can extend the language without breaking older specs.
+func ParseFilterSpec(spec string) FilterSpec {
+	var fs FilterSpec
+	for _, tok := range strings.Fields(spec) {
+		switch {
+		case strings.HasPrefix(tok, "band="):
+			fs.Bands = strings.Split(strings.TrimPrefix(tok, "band="), ",")
+		case strings.HasPrefix(tok, "mode="):
+			fs.Modes = strings.Split(strings.TrimPrefix(tok, "mode="), ",")
+		case strings.HasPrefix(tok, "spotter="):
+			fs.Spotters = strings.FieldsFunc(strings.TrimPrefix(tok, "spotter="), func(r rune) bool {
+				return r == ',' || r == '/'
+			})
+		case strings.HasPrefix(tok, "snr>"):
+			if n, err := strconv.Atoi(strings.TrimPrefix(tok, "snr>")); err == nil {
+				fs.MinSNR = n
+			}
+		}
+	}
+	return fs
+}
+
+// ClusterAdapter knows how to speak one cluster's wire protocol: how to
+// log in, how to turn a raw line into a Spot, and how to translate a
+// FilterSpec into that cluster's native filter/set commands.
+type ClusterAdapter interface {
+	Login(w io.Writer) error
+	ParseLine(line string) (Spot, bool)
+	SendFilter(w io.Writer, spec FilterSpec) error
+}
+
+// loginByCallsign implements the common login sequence: just echo the
+// configured callsign back at the prompt.
+type loginByCallsign struct {
+	Callsign string
+}
+
+func (l loginByCallsign) Login(w io.Writer) error {
+	if l.Callsign == "" {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "%s\n", l.Callsign)
+	return err
+}
+
+// newAdapterByName builds the adapter named by -cluster-type. "auto" is
+// handled separately by detectAdapterFromBanner.
+func newAdapterByName(name, callsign string) ClusterAdapter {
+	switch name {
+	case "arcluster":
+		return NewARClusterAdapter(callsign)
+	case "cccluster":
+		return NewCCClusterAdapter(callsign)
+	case "rbn":
+		return NewRBNAdapter(callsign)
+	default:
+		return NewDXSpiderAdapter(callsign)
+	}
+}
+
+// detectAdapterFromBanner guesses the cluster software from its login
+// banner text, defaulting to DXSpider (the most common) if nothing
+// matches.
+func detectAdapterFromBanner(banner, callsign string) ClusterAdapter {
+	switch {
+	case strings.Contains(banner, "AR-Cluster"):
+		return NewARClusterAdapter(callsign)
+	case strings.Contains(banner, "CC Cluster"), strings.Contains(banner, "CC-Cluster"):
+		return NewCCClusterAdapter(callsign)
+	case strings.Contains(banner, "Reverse Beacon"), strings.Contains(banner, "RBN"):
+		return NewRBNAdapter(callsign)
+	default:
+		return NewDXSpiderAdapter(callsign)
+	}
+}
+
+// adapterVal holds the ClusterAdapter in use for this run. It's written
+// exactly once -- up front from -cluster-type, or lazily from the first
+// banner line in auto mode -- then read from the scanner goroutine.
+var adapterVal atomic.Value
+
+func getAdapter() ClusterAdapter {
+	a, _ := adapterVal.Load().(ClusterAdapter)
+	return a
+}
+
+func setAdapter(a ClusterAdapter) {
+	adapterVal.Store(a)
+}
@@ -0,0 +1,99 @@
+package main
+
+import "strings"
+
+// dxccEntity is a minimal callsign-prefix -> DXCC entity mapping, just
+// enough to decide whether a spotted call is a new entity, which
+// continent it's on (used for continent-based spot filtering), and its
+// display name. A fuller cty.dat-based table would replace this if more
+// precision is ever needed.
+type dxccEntity struct {
+	prefix    string
+	id        int
+	name      string
+	continent string
+}
+
+var dxccTable = []dxccEntity{
+	{"K", 291, "United States", "NA"},
+	{"W", 291, "United States", "NA"},
+	{"N", 291, "United States", "NA"},
+	{"AA", 291, "United States", "NA"},
+	{"AL", 6, "Alaska", "NA"},
+	{"KH6", 110, "Hawaii", "OC"},
+	{"VE", 1, "Canada", "NA"},
+	{"VA", 1, "Canada", "NA"},
+	{"VO", 1, "Canada", "NA"},
+	{"XE", 50, "Mexico", "NA"},
+	{"G", 223, "England", "EU"},
+	{"M", 223, "England", "EU"},
+	{"2E", 223, "England", "EU"},
+	{"DL", 230, "Fed. Republic of Germany", "EU"},
+	{"F", 227, "France", "EU"},
+	{"I", 248, "Italy", "EU"},
+	{"EA", 281, "Spain", "EU"},
+	{"CT", 272, "Portugal", "EU"},
+	{"PA", 263, "Netherlands", "EU"},
+	{"ON", 209, "Belgium", "EU"},
+	{"HB9", 287, "Switzerland", "EU"},
+	{"HB", 287, "Switzerland", "EU"},
+	{"OE", 206, "Austria", "EU"},
+	{"SP", 269, "Poland", "EU"},
+	{"OK", 503, "Czech Republic", "EU"},
+	{"OM", 496, "Slovak Republic", "EU"},
+	{"HA", 239, "Hungary", "EU"},
+	{"9A", 497, "Croatia", "EU"},
+	{"OZ", 221, "Denmark", "EU"},
+	{"SM", 284, "Sweden", "EU"},
+	{"LA", 266, "Norway", "EU"},
+	{"OH", 224, "Finland", "EU"},
+	{"UA", 54, "European Russia", "EU"},
+	{"RA", 54, "European Russia", "EU"},
+	{"JA", 339, "Japan", "AS"},
+	{"BY", 318, "China", "AS"},
+	{"HL", 137, "South Korea", "AS"},
+	{"VK", 150, "Australia", "OC"},
+	{"ZL", 170, "New Zealand", "OC"},
+	{"PY", 108, "Brazil", "SA"},
+	{"LU", 100, "Argentina", "SA"},
+	{"CE", 112, "Chile", "SA"},
+	{"ZS", 462, "South Africa", "AF"},
+}
+
+// lookupEntity resolves a callsign to its DXCC entity by longest
+// matching prefix, returning the zero value (id 0, continent "") if
+// nothing matches.
+func lookupEntity(call string) dxccEntity {
+	call = strings.ToUpper(strings.TrimSpace(call))
+	if idx := strings.IndexByte(call, '/'); idx != -1 {
+		before, after := call[:idx], call[idx+1:]
+		// Prefer whichever side looks like the actual callsign, e.g.
+		// "W1AW/4" -> "W1AW" but "F/W1AW" -> "W1AW".
+		if len(after) > len(before) {
+			call = after
+		} else {
+			call = before
+		}
+	}
+
+	var best dxccEntity
+	bestLen := 0
+	for _, e := range dxccTable {
+		if len(e.prefix) > bestLen && strings.HasPrefix(call, e.prefix) {
+			best = e
+			bestLen = len(e.prefix)
+		}
+	}
+	return best
+}
+
+// dxccForCall resolves a callsign to a DXCC entity id, or 0 if unknown.
+func dxccForCall(call string) int {
+	return lookupEntity(call).id
+}
+
+// continentForCall resolves a callsign to its two-letter continent
+// code (NA, SA, EU, AF, AS, OC, AN), or "" if unknown.
+func continentForCall(call string) string {
+	return lookupEntity(call).continent
+}
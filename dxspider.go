@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// dxSpotPattern matches the "DX de SPOTTER:   FREQ  DXCALL  COMMENT   TIME"
+// line format shared by DXSpider, AR-Cluster, and CC-Cluster.
+var dxSpotPattern = regexp.MustCompile(`^DX de (\S+?)(:?\s*)([0-9.]+)(\s+)(\S+?)(\s+)(.*?)(\s*)([0-9]{4}Z)`)
+
+// parseDXLine parses the classic "DX de" spot line shared by most
+// telnet DX clusters.
+func parseDXLine(line string) (Spot, bool) {
+	m := dxSpotPattern.FindStringSubmatch(line)
+	if m == nil {
+		return Spot{}, false
+	}
+	freqKhz, err := strconv.ParseFloat(m[3], 64)
+	if err != nil {
+		return Spot{}, false
+	}
+	return Spot{
+		Spotter: m[1],
+		FreqKhz: freqKhz,
+		DXCall:  m[5],
+		Comment: m[7],
+		TimeStr: m[9],
+	}, true
+}
+
+// DXSpiderAdapter speaks the classic DXSpider telnet protocol.
+type DXSpiderAdapter struct {
+	loginByCallsign
+}
+
+func NewDXSpiderAdapter(callsign string) *DXSpiderAdapter {
+	return &DXSpiderAdapter{loginByCallsign{callsign}}
+}
+
+func (a *DXSpiderAdapter) ParseLine(line string) (Spot, bool) {
+	return parseDXLine(line)
+}
+
+// SendFilter issues a DXSpider "accept/spot" filter built from spec.
+func (a *DXSpiderAdapter) SendFilter(w io.Writer, spec FilterSpec) error {
+	var parts []string
+	if len(spec.Bands) > 0 {
+		parts = append(parts, "freq "+strings.Join(spec.Bands, ","))
+	}
+	if len(spec.Modes) > 0 {
+		parts = append(parts, "mode "+strings.Join(spec.Modes, ","))
+	}
+	if len(spec.Spotters) > 0 {
+		parts = append(parts, "by "+strings.Join(spec.Spotters, ","))
+	}
+	if spec.MinSNR > 0 {
+		parts = append(parts, fmt.Sprintf("info /%d dB/", spec.MinSNR))
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "accept/spot %s\n", strings.Join(parts, " and "))
+	return err
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+	log "github.com/rs/zerolog/log"
+)
+
+var wsUpgrader = websocket.Upgrader{
+	// The dashboard is meant to be reached from any browser on the LAN,
+	// not just same-origin, so skip the usual origin check.
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+//go:embed templates/index.html
+var httpTemplates embed.FS
+
+var indexTemplate = template.Must(template.ParseFS(httpTemplates, "templates/index.html"))
+
+// serveHTTP runs the optional live-spot dashboard until the process
+// exits. It logs rather than fatals on listener errors so a bad -http
+// address doesn't take down spot processing.
+func serveHTTP(addr string, store *SpotStore, historyWindow time.Duration) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := indexTemplate.Execute(w, nil); err != nil {
+			log.Error().Err(err).Msg("rendering dashboard template")
+		}
+	})
+	mux.HandleFunc("/spots", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, store.Snapshot())
+	})
+	mux.HandleFunc("/spotters", func(w http.ResponseWriter, r *http.Request) {
+		window := historyWindow
+		if m, err := strconv.Atoi(r.URL.Query().Get("minutes")); err == nil && m > 0 {
+			window = time.Duration(m) * time.Minute
+			if window > historyWindow {
+				window = historyWindow
+			}
+		}
+		writeJSON(w, store.SpottersSince(time.Now().Add(-window)))
+	})
+	mux.HandleFunc("/spotscount", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, store.BandCounts())
+	})
+	mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		streamSpots(w, r, store)
+	})
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		serveWSSpots(w, r, store)
+	})
+
+	log.Info().Str("addr", addr).Msg("starting HTTP dashboard")
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Error().Err(err).Msg("HTTP dashboard stopped")
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error().Err(err).Msg("encoding JSON response")
+	}
+}
+
+// streamSpots serves /stream as an SSE feed: an initial burst of "add"
+// events for every currently active spot, followed by live events as
+// the store changes.
+func streamSpots(w http.ResponseWriter, r *http.Request, store *SpotStore) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ch, cancel := store.Subscribe()
+	defer cancel()
+
+	for _, rec := range store.Snapshot() {
+		writeSSE(w, SpotEvent{Type: "add", Spot: rec})
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			writeSSE(w, ev)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeSSE(w http.ResponseWriter, ev SpotEvent) {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		log.Error().Err(err).Msg("marshaling SSE event")
+		return
+	}
+	fmt.Fprintf(w, "data: %s\n\n", data)
+}
+
+// serveWSSpots serves /ws: the websocket equivalent of /stream, for
+// browser dashboards that would rather not use SSE.
+func serveWSSpots(w http.ResponseWriter, r *http.Request, store *SpotStore) {
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("upgrading websocket")
+		return
+	}
+	defer conn.Close()
+
+	ch, cancel := store.Subscribe()
+	defer cancel()
+
+	for _, rec := range store.Snapshot() {
+		if err := conn.WriteJSON(SpotEvent{Type: "add", Spot: rec}); err != nil {
+			return
+		}
+	}
+
+	for ev := range ch {
+		if err := conn.WriteJSON(ev); err != nil {
+			return
+		}
+	}
+}
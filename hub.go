@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/rs/zerolog/log"
+)
+
+// clusterClient is one downstream consumer of the rebroadcast stream: a
+// telnet session accepted by -listen. /ws websocket clients are not
+// clusterClients; they subscribe to the SpotStore (see serveWSSpots).
+type clusterClient struct {
+	lines chan string
+}
+
+// ClusterHub fans the raw upstream cluster line stream out to any
+// number of local clients, so LAN loggers don't each need their own
+// upstream cluster session. A client that falls behind has lines
+// dropped rather than stalling the upstream reader.
+type ClusterHub struct {
+	mu      sync.Mutex
+	clients map[*clusterClient]struct{}
+}
+
+func NewClusterHub() *ClusterHub {
+	return &ClusterHub{clients: map[*clusterClient]struct{}{}}
+}
+
+// Broadcast fans line out to every connected client.
+func (h *ClusterHub) Broadcast(line string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		select {
+		case c.lines <- line:
+		default:
+			log.Warn().Msg("rebroadcast client too slow, dropping line")
+		}
+	}
+}
+
+// Join registers a new client, seeded with a backfill of store's
+// currently active spots rendered as synthetic "DX de" lines so the
+// client can parse them exactly like a live spot.
+func (h *ClusterHub) Join(store *SpotStore) *clusterClient {
+	c := &clusterClient{lines: make(chan string, 256)}
+
+	h.mu.Lock()
+	h.clients[c] = struct{}{}
+	h.mu.Unlock()
+
+	for _, rec := range store.Snapshot() {
+		select {
+		case c.lines <- formatDXLine(rec):
+		default:
+			// The client's drain loop hasn't started yet (it starts
+			// only once Join returns), so a busy band with more active
+			// spots than the buffer can hold would otherwise block
+			// this call forever. Same drop-on-slow-consumer semantics
+			// as Broadcast.
+			log.Warn().Msg("rebroadcast client too slow, dropping backfill line")
+		}
+	}
+	return c
+}
+
+// Leave unregisters a client and closes its line channel.
+func (h *ClusterHub) Leave(c *clusterClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[c]; ok {
+		delete(h.clients, c)
+		close(c.lines)
+	}
+}
+
+// formatDXLine renders a spotRecord back into the classic "DX de ..."
+// wire format used by every adapter's dxSpotPattern.
+func formatDXLine(rec spotRecord) string {
+	return fmt.Sprintf(
+		"DX de %s:%9.1f  %-12s %s %s",
+		rec.Spotter, rec.FreqKhz, rec.DXCall, rec.Comment, time.Now().UTC().Format("1504Z"),
+	)
+}
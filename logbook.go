@@ -0,0 +1,170 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	log "github.com/rs/zerolog/log"
+)
+
+// Logbook is a SQLite-backed record of past QSOs, used to flag incoming
+// spots as a new DXCC entity, a new band/mode for a known entity, or a
+// simple dupe.
+type Logbook struct {
+	db *sql.DB
+}
+
+// WorkedFlags summarizes how a spotted callsign compares against the log.
+type WorkedFlags struct {
+	NewDXCC bool
+	NewBand bool
+	NewMode bool
+	Worked  bool
+}
+
+const logbookSchema = `
+CREATE TABLE IF NOT EXISTS qsos (
+	call      TEXT NOT NULL,
+	band      TEXT NOT NULL,
+	mode      TEXT NOT NULL,
+	dxcc      INTEGER NOT NULL,
+	worked_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS qsos_call_idx ON qsos(call);
+CREATE INDEX IF NOT EXISTS qsos_dxcc_idx ON qsos(dxcc);
+`
+
+// OpenLogbook opens (creating if necessary) the SQLite-backed logbook at path.
+func OpenLogbook(path string) (*Logbook, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(logbookSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Logbook{db: db}, nil
+}
+
+// Close releases the underlying database handle.
+func (l *Logbook) Close() error {
+	return l.db.Close()
+}
+
+// Check reports which dupe-filtering flags apply to a spot of call on
+// band in mode, without recording anything.
+func (l *Logbook) Check(call, band, mode string) WorkedFlags {
+	call = strings.ToUpper(call)
+	dxcc := dxccForCall(call)
+
+	var flags WorkedFlags
+	var n int
+
+	if err := l.db.QueryRow(`SELECT count(*) FROM qsos WHERE call = ?`, call).Scan(&n); err != nil {
+		log.Error().Err(err).Msg("logbook: checking worked")
+		return flags
+	}
+	flags.Worked = n > 0
+
+	if dxcc == 0 {
+		// dxccTable only covers a handful of entities, so every
+		// unresolved callsign shares the same zero-value id. Comparing
+		// against it as a real DXCC would make the first logged QSO
+		// from any uncovered country mark every other uncovered
+		// country's spots as worked too, so just always report these
+		// as new instead of querying by the shared sentinel id.
+		flags.NewDXCC = true
+		flags.NewBand = true
+		flags.NewMode = true
+		return flags
+	}
+
+	if err := l.db.QueryRow(`SELECT count(*) FROM qsos WHERE dxcc = ?`, dxcc).Scan(&n); err != nil {
+		log.Error().Err(err).Msg("logbook: checking DXCC")
+		return flags
+	}
+	flags.NewDXCC = n == 0
+
+	if err := l.db.QueryRow(`SELECT count(*) FROM qsos WHERE dxcc = ? AND band = ?`, dxcc, band).Scan(&n); err != nil {
+		log.Error().Err(err).Msg("logbook: checking band")
+		return flags
+	}
+	flags.NewBand = n == 0
+
+	if err := l.db.QueryRow(`SELECT count(*) FROM qsos WHERE dxcc = ? AND mode = ?`, dxcc, mode).Scan(&n); err != nil {
+		log.Error().Err(err).Msg("logbook: checking mode")
+		return flags
+	}
+	flags.NewMode = n == 0
+
+	return flags
+}
+
+// Record logs a worked contact so future spots of the same call/entity
+// are no longer flagged as new.
+func (l *Logbook) Record(call, band, mode string, workedAt time.Time) error {
+	call = strings.ToUpper(call)
+	dxcc := dxccForCall(call)
+	_, err := l.db.Exec(
+		`INSERT INTO qsos(call, band, mode, dxcc, worked_at) VALUES (?, ?, ?, ?, ?)`,
+		call, band, mode, dxcc, workedAt,
+	)
+	return err
+}
+
+// ImportADIF seeds the logbook from an ADIF export (e.g. from Log4OM or
+// N1MM+) so worked-before state survives across restarts.
+func ImportADIF(l *Logbook, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	n := 0
+	for _, rec := range splitADIFRecords(string(data)) {
+		fields := parseADIFRecord(rec)
+		call, band, mode := fields["call"], fields["band"], fields["mode"]
+		if call == "" || band == "" {
+			continue
+		}
+
+		workedAt := time.Now()
+		if qsoDate, timeOn := fields["qso_date"], fields["time_on"]; qsoDate != "" {
+			if len(timeOn) == 4 {
+				if t, err := time.Parse("20060102 1504", qsoDate+" "+timeOn); err == nil {
+					workedAt = t
+				}
+			}
+		}
+
+		if err := l.Record(call, strings.ToLower(band), normalizeMode(mode), workedAt); err != nil {
+			return err
+		}
+		n++
+	}
+	log.Info().Int("qsos", n).Str("file", path).Msg("imported ADIF logbook")
+	return nil
+}
+
+// flagTag returns a short annotation prefixed to a spot's comment so
+// operators (and the radio's spot display) can tell at a glance why it
+// was flagged. The highest-priority matching flag wins.
+func flagTag(flags WorkedFlags) string {
+	switch {
+	case flags.NewDXCC:
+		return "[NEW DXCC] "
+	case flags.NewBand:
+		return "[NEW BAND] "
+	case flags.NewMode:
+		return "[NEW MODE] "
+	case flags.Worked:
+		return "[WKD] "
+	default:
+		return ""
+	}
+}
@@ -25,13 +25,22 @@ import (
 const SpotNotFoundError = 0x500000BC
 
 var cfg struct {
-	RadioIP       string
-	Station       string
-	Callsign      string
-	ClusterServer string
-	QRT           bool
-	OnePerBand    bool
-	Timeout       time.Duration
+	RadioIP        string
+	Station        string
+	Callsign       string
+	ClusterServer  string
+	QRT            bool
+	OnePerBand     bool
+	Timeout        time.Duration
+	LogbookPath    string
+	AdifImport     string
+	HTTPAddr       string
+	HTTPHistory    time.Duration
+	ClusterType    string
+	Filter         string
+	ListenAddr     string
+	ListenFiltered bool
+	RulesPath      string
 }
 
 func init() {
@@ -42,28 +51,89 @@ func init() {
 	flag.DurationVar(&cfg.Timeout, "timeout", 5*time.Minute, "spot persistence timeout")
 	flag.BoolVar(&cfg.QRT, "qrt", true, "delete spots with QRT in comment")
 	flag.BoolVar(&cfg.OnePerBand, "one-per-band", true, "expect a given callsign only once per band")
+	flag.StringVar(&cfg.LogbookPath, "logbook", "", "path to SQLite logbook database (enables NewDXCC/NewBand/NewMode/Worked flags)")
+	flag.StringVar(&cfg.AdifImport, "adif", "", "ADIF file to import into the logbook at startup")
+	flag.StringVar(&cfg.HTTPAddr, "http", "", "address to serve the live-spot dashboard on, e.g. :8080 (disabled if empty)")
+	flag.DurationVar(&cfg.HTTPHistory, "http-history", 60*time.Minute, "how much spotter history the dashboard's /spotters endpoint can report")
+	flag.StringVar(&cfg.ClusterType, "cluster-type", "auto", "cluster protocol to speak: auto, dxspider, arcluster, cccluster, or rbn")
+	flag.StringVar(&cfg.Filter, "filter", "", "filter spec to send after login, e.g. \"band=20m,15m mode=CW,FT8 snr>10 spotter=NA/EU\"")
+	flag.StringVar(&cfg.ListenAddr, "listen", "", "address to serve a local cluster rebroadcast on, e.g. :7300 (disabled if empty)")
+	flag.BoolVar(&cfg.ListenFiltered, "listen-filtered", false, "apply this operator's -rules drops to the -listen rebroadcast too, instead of passing through everything seen upstream")
+	flag.StringVar(&cfg.RulesPath, "rules", "", "YAML file of forward/drop/recolor rules (each with an optional tag and console color) and per-band spot budgets")
 }
 
-func logToConsole(w io.Writer, m []string, remove bool) {
-	commentColor := color.FgLightCyan
+// clusterHub fans out the raw upstream line stream to -listen clients.
+var clusterHub = NewClusterHub()
+
+// ruleEngine is nil unless -rules was given, in which case it decides
+// whether to forward, drop, or recolor each spot before it reaches the
+// radio, and enforces any configured per-band spot budgets.
+var ruleEngine *RuleEngine
+
+// logbook is nil unless -logbook was given, in which case spots are
+// annotated with dupe-filtering flags from it.
+var logbook *Logbook
+
+// filterOnce ensures -filter is sent exactly once. Sending it is gated
+// on login having finished first (see loginDone in main): most cluster
+// software ignores or rejects a filter command sent before login.
+var filterOnce sync.Once
+
+func sendFilterOnce(w io.Writer, a ClusterAdapter) {
+	if cfg.Filter == "" {
+		return
+	}
+	filterOnce.Do(func() {
+		if err := a.SendFilter(w, ParseFilterSpec(cfg.Filter)); err != nil {
+			log.Error().Err(err).Msg("sending cluster filter")
+		}
+	})
+}
+
+// sendFilterLoggedIn waits for loginDone, then for the adapter to be
+// known (it's usually already set by then, but the banner can in
+// principle arrive after login finishes), and only then sends -filter.
+func sendFilterLoggedIn(w io.Writer, loginDone <-chan struct{}) {
+	<-loginDone
+	for getAdapter() == nil {
+		time.Sleep(100 * time.Millisecond)
+	}
+	sendFilterOnce(w, getAdapter())
+}
+
+func flagColor(flags WorkedFlags) color.Color {
+	switch {
+	case flags.NewDXCC:
+		return color.FgLightYellow
+	case flags.NewBand, flags.NewMode:
+		return color.FgLightGreen
+	case flags.Worked:
+		return color.FgGray
+	default:
+		return color.FgLightCyan
+	}
+}
+
+func logToConsole(w io.Writer, sp Spot, remove bool, flags WorkedFlags, ruleTag, ruleColor string) {
+	commentColor := flagColor(flags)
 	if remove {
 		commentColor = color.FgLightRed
 	}
 
-	freqKhz, err := strconv.ParseFloat(m[3], 64)
-	if err != nil {
-		log.Error().Err(err).Send()
-		return
+	ruleLabel := ""
+	if ruleTag != "" {
+		ruleLabel = ruleTagColor(ruleColor).Render(ruleTagPrefix(ruleTag))
 	}
+
 	fmt.Fprintln(
 		w,
 		color.FgLightGreen.Render("DX de")+
-			" "+color.FgYellow.Render(m[1])+
-			m[2]+color.FgLightBlue.Render(m[3])+
-			m[4]+color.FgMagenta.Render(m[5])+
-			m[6]+commentColor.Render(m[7])+
-			m[8]+m[9]+
-			" "+color.FgLightGreen.Render(getBand(freqKhz)),
+			" "+color.FgYellow.Render(sp.Spotter)+
+			"  "+color.FgLightBlue.Render(fmt.Sprintf("%g", sp.FreqKhz))+
+			"  "+color.FgMagenta.Render(sp.DXCall)+
+			"  "+ruleLabel+commentColor.Render(flagTag(flags)+sp.Comment)+
+			"  "+sp.TimeStr+
+			" "+color.FgLightGreen.Render(getBand(sp.FreqKhz)),
 	)
 }
 
@@ -107,36 +177,35 @@ type spotKey struct {
 	call string
 }
 
-type spot struct {
-	id      int
-	expires time.Time
-}
-
-var spotIds = map[spotKey]spot{}
+// store holds the set of spots currently pushed to the radio; it backs
+// both the dupe-detection logic below and the optional HTTP dashboard.
+var store = NewSpotStore()
 
-func sendToFlex(fc *flexclient.FlexClient, m []string, remove bool) {
-	spotCall, freq, dxCall, comment := m[1], m[3], m[5], m[7]
-	freqKhz, err := strconv.ParseFloat(freq, 64)
-	if err != nil {
-		log.Error().Err(err).Send()
-		return
-	}
-	var key spotKey
+// spotKeyFor computes the dedup key a spot is tracked under, honoring
+// -one-per-band.
+func spotKeyFor(sp Spot) spotKey {
 	if cfg.OnePerBand {
-		key = spotKey{freq: getBand(freqKhz), call: dxCall}
-	} else {
-		key = spotKey{freq: fmt.Sprintf("%.0f", freqKhz), call: dxCall} // round to nearest kHz
+		return spotKey{freq: getBand(sp.FreqKhz), call: sp.DXCall}
 	}
+	return spotKey{freq: fmt.Sprintf("%.0f", sp.FreqKhz), call: sp.DXCall} // round to nearest kHz
+}
+
+func sendToFlex(fc *flexclient.FlexClient, sp Spot, remove bool, flags WorkedFlags, ruleTag string) {
+	spotCall, dxCall, comment := sp.Spotter, sp.DXCall, sp.Comment
+	if !remove {
+		comment = ruleTagPrefix(ruleTag) + flagTag(flags) + comment
+	}
+
+	key := spotKeyFor(sp)
 
 	strings.ReplaceAll(spotCall, " ", "\x7f")
-	strings.ReplaceAll(freq, " ", "\x7f")
 	strings.ReplaceAll(dxCall, " ", "\x7f")
 	strings.ReplaceAll(comment, " ", "\x7f")
 
 	if remove {
 		removeSpot(fc, key)
 	} else {
-		addSpot(fc, key, spotCall, freqKhz, dxCall, comment)
+		addSpot(fc, key, spotCall, sp.FreqKhz, dxCall, comment)
 	}
 }
 
@@ -145,10 +214,10 @@ func addSpot(fc *flexclient.FlexClient, key spotKey, spotCall string, freqKhz fl
 	fields := fmt.Sprintf("rx_freq=%f callsign=%s spotter_callsign=%s comment=%s lifetime_seconds=%d", freqKhz/1000.0, dxCall, spotCall, comment, lifetimeSecs)
 
 	var res flexclient.CmdResult
-	sp, existed := spotIds[key]
+	rec, existed := store.Get(key)
 	if existed {
 		// Spot already exists for band/mode, update instead of adding
-		res = fc.SendAndWait(fmt.Sprintf("spot set %d %s", sp.id, fields))
+		res = fc.SendAndWait(fmt.Sprintf("spot set %d %s", rec.ID, fields))
 	}
 	if !existed || res.Error == SpotNotFoundError {
 		res = fc.SendAndWait(fmt.Sprintf("spot add %s", fields))
@@ -165,33 +234,36 @@ func addSpot(fc *flexclient.FlexClient, key spotKey, spotCall string, freqKhz fl
 			log.Error().Err(err).Msg("atoi")
 			return
 		}
-		sp.id = id
+		rec.ID = id
 	}
-	spotIds[key] = spot{id: sp.id, expires: time.Now().Add(cfg.Timeout)}
+
+	rec.FreqKhz = freqKhz
+	rec.Band = getBand(freqKhz)
+	rec.DXCall = dxCall
+	rec.Spotter = spotCall
+	rec.Comment = comment
+	rec.Expires = time.Now().Add(cfg.Timeout)
+	store.Set(key, rec)
 }
 
 func removeSpot(fc *flexclient.FlexClient, key spotKey) {
-	spot, ok := spotIds[key]
+	rec, ok := store.Get(key)
 	if ok {
-		res := fc.SendAndWait(fmt.Sprintf("spot remove %d", spot.id))
+		res := fc.SendAndWait(fmt.Sprintf("spot remove %d", rec.ID))
 		if res.Error != 0 && res.Error != SpotNotFoundError {
 			log.Error().Uint32("error", res.Error).Msg(res.Message)
 		}
 	}
-	delete(spotIds, key)
+	store.Delete(key)
 }
 
 func cleanupSpots() {
-	now := time.Now()
-	for k, v := range spotIds {
-		if v.expires.Before(now) {
-			delete(spotIds, k)
-		}
-	}
+	store.Cleanup(cfg.HTTPHistory, func(key spotKey, band string) {
+		ruleEngine.Forget(band, key)
+	})
 }
 
 func main() {
-	spotPattern := regexp.MustCompile(`^DX de (\S+?)(:?\s*)([0-9.]+)(\s+)(\S+?)(\s+)(.*?)(\s*)([0-9]{4}Z)`)
 	qrtPattern := regexp.MustCompile(`\b(?i:QRT)\b`)
 
 	promptSuffixes := []string{">", "> ", ":", ": "}
@@ -208,6 +280,37 @@ func main() {
 		log.Fatal().Msg("-server is required")
 	}
 
+	if cfg.LogbookPath != "" {
+		var err error
+		logbook, err = OpenLogbook(cfg.LogbookPath)
+		if err != nil {
+			log.Fatal().Err(err).Msg("opening logbook")
+		}
+		defer logbook.Close()
+
+		if cfg.AdifImport != "" {
+			if err := ImportADIF(logbook, cfg.AdifImport); err != nil {
+				log.Fatal().Err(err).Msg("importing ADIF logbook")
+			}
+		}
+	}
+
+	if cfg.RulesPath != "" {
+		rcfg, err := LoadRuleConfig(cfg.RulesPath)
+		if err != nil {
+			log.Fatal().Err(err).Msg("loading rules config")
+		}
+		ruleEngine = NewRuleEngine(rcfg)
+	}
+
+	if cfg.HTTPAddr != "" {
+		go serveHTTP(cfg.HTTPAddr, store, cfg.HTTPHistory)
+	}
+
+	if cfg.ClusterType != "auto" {
+		setAdapter(newAdapterByName(cfg.ClusterType, cfg.Callsign))
+	}
+
 	fc, err := flexclient.NewFlexClient(cfg.RadioIP)
 	if err != nil {
 		log.Fatal().Err(err).Send()
@@ -218,6 +321,10 @@ func main() {
 		log.Fatal().Err(err).Send()
 	}
 
+	if cfg.ListenAddr != "" {
+		go serveClusterListener(cfg.ListenAddr, clusterHub, store, tc)
+	}
+
 	prompt := color.FgLightMagenta.Render("cluster") + "> "
 	rl, err := readline.New(prompt)
 	if err != nil {
@@ -247,16 +354,62 @@ func main() {
 		wg.Done()
 	}()
 
+	// loginDone is closed once the cluster login sequence below has
+	// either completed or been skipped (no -callsign configured). -filter
+	// must not be sent before then, so sendFilterLoggedIn waits on it.
+	loginDone := make(chan struct{})
+	go sendFilterLoggedIn(tc, loginDone)
+
 	go func() {
 		lines := bufio.NewScanner(tc)
 		for lines.Scan() {
 			line := lines.Text()
-			if m := spotPattern.FindStringSubmatch(line); m != nil {
-				remove := cfg.QRT && qrtPattern.MatchString(m[7])
-				logToConsole(rl.Stdout(), m, remove)
-				sendToFlex(fc, m, remove)
+
+			if getAdapter() == nil {
+				setAdapter(detectAdapterFromBanner(line, cfg.Callsign))
+			}
+
+			if sp, ok := getAdapter().ParseLine(line); ok {
+				remove := cfg.QRT && qrtPattern.MatchString(sp.Comment)
+
+				var ruleTag, ruleColor string
+				var dropped bool
+				if !remove && ruleEngine != nil {
+					decision := ruleEngine.Evaluate(sp, spotAge(sp.TimeStr), continentForCall(sp.DXCall))
+					if decision.Action == ActionDrop {
+						dropped = true
+					} else {
+						ruleTag = decision.Tag
+						ruleColor = decision.Color
+						ruleEngine.AdmitBand(getBand(sp.FreqKhz), spotKeyFor(sp), func(k spotKey) { removeSpot(fc, k) })
+					}
+				}
+
+				// -listen exists so other loggers can share this
+				// operator's upstream session, a different audience
+				// from -rules' own noise reduction -- so by default a
+				// rule-dropped spot is still rebroadcast. -listen-filtered
+				// opts into applying -rules here too.
+				if !dropped || !cfg.ListenFiltered {
+					clusterHub.Broadcast(line)
+				}
+
+				if dropped {
+					continue
+				}
+
+				var flags WorkedFlags
+				if logbook != nil && !remove {
+					flags = logbook.Check(sp.DXCall, getBand(sp.FreqKhz), inferMode(sp.FreqKhz))
+				}
+				logToConsole(rl.Stdout(), sp, remove, flags, ruleTag, ruleColor)
+				sendToFlex(fc, sp, remove, flags, ruleTag)
 				cleanupSpots()
 			} else {
+				// Not a spot line (banner/prompt/chat) -- not subject to
+				// rule filtering, so it's broadcast immediately.
+				clusterHub.Broadcast(line)
+
 				var prompt = false
 				for _, suffix := range promptSuffixes {
 					if strings.HasSuffix(line, suffix) {
@@ -290,8 +443,15 @@ func main() {
 
 	if cfg.Callsign != "" {
 		time.Sleep(time.Second)
-		fmt.Fprintf(tc, "%s\n", cfg.Callsign)
+		if a := getAdapter(); a != nil {
+			if err := a.Login(tc); err != nil {
+				log.Error().Err(err).Msg("cluster login")
+			}
+		} else {
+			fmt.Fprintf(tc, "%s\n", cfg.Callsign)
+		}
 	}
+	close(loginDone)
 
 	wg.Wait()
 }
@@ -0,0 +1,89 @@
+package main
+
+import "strings"
+
+// modeSegment is one sub-band boundary: from minKhz up to the next
+// segment's minKhz, the band plan calls this mode.
+type modeSegment struct {
+	minKhz float64
+	mode   string
+}
+
+// modeSegments is a simplified IARU Region 2 (ITU/ARRL) band plan, used
+// to infer operating mode from frequency alone since the cluster feed
+// doesn't report it directly.
+var modeSegments = map[string][]modeSegment{
+	"160m": {{1800, "CW"}, {1840, "Data"}, {1900, "Phone"}},
+	"80m":  {{3500, "CW"}, {3580, "Data"}, {3600, "Phone"}},
+	"40m":  {{7000, "CW"}, {7040, "Data"}, {7125, "Phone"}},
+	"30m":  {{10100, "CW"}, {10130, "Data"}},
+	"20m":  {{14000, "CW"}, {14070, "Data"}, {14100, "Phone"}},
+	"17m":  {{18068, "CW"}, {18095, "Data"}, {18110, "Phone"}},
+	"15m":  {{21000, "CW"}, {21070, "Data"}, {21200, "Phone"}},
+	"12m":  {{24890, "CW"}, {24920, "Data"}, {24930, "Phone"}},
+	"10m":  {{28000, "CW"}, {28070, "Data"}, {28300, "Phone"}},
+}
+
+// ft8CallingFreqs are the common FT8 calling frequencies (kHz); a spot
+// within 1 kHz of one of these is reported as FT8 rather than plain Data.
+var ft8CallingFreqs = []float64{1840, 3573, 5357, 7074, 10136, 14074, 18100, 21074, 24915, 28074, 50313}
+
+func isFT8(freqKhz float64) bool {
+	for _, f := range ft8CallingFreqs {
+		if freqKhz >= f-1 && freqKhz <= f+1 {
+			return true
+		}
+	}
+	return false
+}
+
+// inferMode guesses the operating mode of a spot from its frequency,
+// using modeSegments for the HF bands it lists, FM for 2m and up, and
+// SSB for any other band (including 60m and 6m).
+func inferMode(freqKhz float64) string {
+	if isFT8(freqKhz) {
+		return "FT8"
+	}
+
+	band := getBand(freqKhz)
+	if segs, ok := modeSegments[band]; ok {
+		mode := segs[0].mode
+		for _, s := range segs {
+			if freqKhz >= s.minKhz {
+				mode = s.mode
+			}
+		}
+		if mode == "Phone" {
+			return "SSB"
+		}
+		return mode
+	}
+
+	switch band {
+	case "2m", "125cm", "70cm", "900M", "1240M", "microwave":
+		return "FM"
+	default:
+		return "SSB"
+	}
+}
+
+// normalizeMode buckets a raw ADIF MODE field (as imported by ImportADIF)
+// into the same coarse taxonomy inferMode produces from frequency alone,
+// so Logbook.Check's mode comparison actually lines up with live spots.
+// ADIF records the real submode (RTTY, PSK31, FT4, JS8, ...), which
+// inferMode has no way to tell apart by frequency, so anything that
+// isn't CW/FT8/a voice mode is bucketed as "Data".
+func normalizeMode(adifMode string) string {
+	switch strings.ToUpper(adifMode) {
+	case "CW":
+		return "CW"
+	case "FT8":
+		return "FT8"
+	case "SSB", "USB", "LSB", "AM", "DIGITALVOICE":
+		return "SSB"
+	case "FM":
+		return "FM"
+	default:
+		return "Data"
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// rbnCommentPattern extracts the SNR/WPM fields RBN skimmers append to
+// the comment, e.g. "CW 15 dB 22 WPM CQ".
+var rbnCommentPattern = regexp.MustCompile(`(\d+)\s*dB\s+(\d+)\s*WPM`)
+
+// RBNAdapter speaks the Reverse Beacon Network's skimmer aggregator
+// telnet feed. Spot lines use the same base format as DXSpider, but the
+// comment carries SNR/WPM that the base parser doesn't know about.
+type RBNAdapter struct {
+	loginByCallsign
+}
+
+func NewRBNAdapter(callsign string) *RBNAdapter {
+	return &RBNAdapter{loginByCallsign{callsign}}
+}
+
+func (a *RBNAdapter) ParseLine(line string) (Spot, bool) {
+	sp, ok := parseDXLine(line)
+	if !ok {
+		return sp, false
+	}
+	if m := rbnCommentPattern.FindStringSubmatch(sp.Comment); m != nil {
+		sp.SNR, _ = strconv.Atoi(m[1])
+		sp.WPM, _ = strconv.Atoi(m[2])
+	}
+	return sp, true
+}
+
+// SendFilter is a no-op: the RBN aggregator feed doesn't support
+// per-connection filtering, so any band/mode/SNR narrowing has to
+// happen client-side instead (e.g. via -rules).
+func (a *RBNAdapter) SendFilter(w io.Writer, spec FilterSpec) error {
+	return nil
+}
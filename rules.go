@@ -0,0 +1,216 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gookit/color"
+	"gopkg.in/yaml.v3"
+)
+
+// RuleAction is what the rule engine decides to do with a spot.
+type RuleAction string
+
+const (
+	ActionForward RuleAction = "forward"
+	ActionDrop    RuleAction = "drop"
+	ActionRecolor RuleAction = "recolor"
+)
+
+// Rule is one predicate/action pair in the filter DSL, loaded from the
+// -rules YAML file. Empty predicate lists match anything; a zero MaxAge
+// means no age limit. The first matching rule wins.
+type Rule struct {
+	Tag        string        `yaml:"tag"`
+	Color      string        `yaml:"color"`
+	Bands      []string      `yaml:"bands"`
+	Modes      []string      `yaml:"modes"`
+	Continents []string      `yaml:"continents"`
+	MaxAge     time.Duration `yaml:"max_age"`
+	Action     RuleAction    `yaml:"action"`
+}
+
+// RuleConfig is the -rules YAML file: an ordered list of rules plus a
+// per-band cap on how many active spots that band may hold at once.
+type RuleConfig struct {
+	Rules       []Rule         `yaml:"rules"`
+	BandBudgets map[string]int `yaml:"band_budgets"`
+}
+
+// LoadRuleConfig reads and parses a -rules YAML file.
+func LoadRuleConfig(path string) (*RuleConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg RuleConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Decision is the outcome of evaluating a spot against the rule set.
+type Decision struct {
+	Action RuleAction
+	Tag    string
+	Color  string
+}
+
+// RuleEngine evaluates spots against a RuleConfig and enforces its
+// per-band spot budgets with LRU eviction.
+type RuleEngine struct {
+	cfg *RuleConfig
+
+	mu        sync.Mutex
+	bandOrder map[string][]spotKey
+}
+
+// NewRuleEngine wraps cfg for evaluation. A nil cfg is valid and makes
+// every spot forward with no tag.
+func NewRuleEngine(cfg *RuleConfig) *RuleEngine {
+	return &RuleEngine{cfg: cfg, bandOrder: map[string][]spotKey{}}
+}
+
+// Evaluate returns the first matching rule's decision, defaulting to a
+// plain forward if nothing in the rule set matches.
+func (e *RuleEngine) Evaluate(sp Spot, age time.Duration, continent string) Decision {
+	if e == nil || e.cfg == nil {
+		return Decision{Action: ActionForward}
+	}
+
+	mode := inferMode(sp.FreqKhz)
+	band := getBand(sp.FreqKhz)
+
+	for _, r := range e.cfg.Rules {
+		if len(r.Bands) > 0 && !containsFold(r.Bands, band) {
+			continue
+		}
+		if len(r.Modes) > 0 && !containsFold(r.Modes, mode) {
+			continue
+		}
+		if len(r.Continents) > 0 && !containsFold(r.Continents, continent) {
+			continue
+		}
+		if r.MaxAge > 0 && age > r.MaxAge {
+			continue
+		}
+		return Decision{Action: r.Action, Tag: r.Tag, Color: r.Color}
+	}
+	return Decision{Action: ActionForward}
+}
+
+// ruleColors maps a rule's optional color name to the display color
+// logToConsole renders its tag with.
+var ruleColors = map[string]color.Color{
+	"red":     color.FgLightRed,
+	"green":   color.FgLightGreen,
+	"yellow":  color.FgLightYellow,
+	"blue":    color.FgLightBlue,
+	"magenta": color.FgMagenta,
+	"cyan":    color.FgCyan,
+	"gray":    color.FgGray,
+	"white":   color.FgWhite,
+}
+
+// ruleTagColor resolves a rule's color name to a display color, falling
+// back to plain cyan for an empty or unknown name.
+func ruleTagColor(name string) color.Color {
+	if c, ok := ruleColors[strings.ToLower(name)]; ok {
+		return c
+	}
+	return color.FgCyan
+}
+
+func containsFold(list []string, want string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// AdmitBand enforces band's spot budget (if configured in BandBudgets):
+// it records key as the most recently spotted entry for band, and calls
+// evict for the oldest entries once the budget is exceeded.
+func (e *RuleEngine) AdmitBand(band string, key spotKey, evict func(spotKey)) {
+	if e == nil || e.cfg == nil {
+		return
+	}
+	budget, ok := e.cfg.BandBudgets[band]
+	if !ok || budget <= 0 {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	order := e.bandOrder[band]
+	for i, k := range order {
+		if k == key {
+			order = append(order[:i], order[i+1:]...)
+			break
+		}
+	}
+	order = append(order, key)
+
+	for len(order) > budget {
+		oldest := order[0]
+		order = order[1:]
+		evict(oldest)
+	}
+	e.bandOrder[band] = order
+}
+
+// Forget removes key from band's admission order without evicting
+// anything, for use when a spot leaves the store via normal TTL expiry
+// rather than through AdmitBand's own budget eviction. Without this,
+// bandOrder only ever grows or shrinks via explicit admit/evict calls,
+// so a budget ends up capping total admissions ever rather than active
+// spots: a band that's gone quiet and picked back up stays under-filled
+// until its stale entries are slowly flushed out one new spot at a time.
+func (e *RuleEngine) Forget(band string, key spotKey) {
+	if e == nil || e.cfg == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	order := e.bandOrder[band]
+	for i, k := range order {
+		if k == key {
+			e.bandOrder[band] = append(order[:i], order[i+1:]...)
+			return
+		}
+	}
+}
+
+// ruleTagPrefix renders a matched rule's tag the same way flagTag
+// renders dupe-filtering flags, so it can be prepended to a spot's
+// comment and console rendering.
+func ruleTagPrefix(tag string) string {
+	if tag == "" {
+		return ""
+	}
+	return "[" + strings.ToUpper(tag) + "] "
+}
+
+// spotAge estimates how long ago a cluster-reported HHMMZ timestamp
+// was, assuming it falls within the last 24 hours.
+func spotAge(timeStr string) time.Duration {
+	t, err := time.Parse("1504Z", timeStr)
+	if err != nil {
+		return 0
+	}
+	now := time.Now().UTC()
+	spotted := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+	age := now.Sub(spotted)
+	if age < 0 {
+		age += 24 * time.Hour
+	}
+	return age
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// spotRecord is the rich, JSON-friendly view of a live spot kept for the
+// HTTP dashboard (the radio/console path itself only cares about the id).
+type spotRecord struct {
+	ID      int       `json:"id"`
+	FreqKhz float64   `json:"freq"`
+	Band    string    `json:"band"`
+	DXCall  string    `json:"dxcall"`
+	Spotter string    `json:"spotter"`
+	Comment string    `json:"comment"`
+	Expires time.Time `json:"expires"`
+}
+
+// SpotEvent is published to dashboard subscribers whenever a spot is
+// added, updated, or removed.
+type SpotEvent struct {
+	Type string     `json:"type"` // "add" or "remove"
+	Spot spotRecord `json:"spot"`
+}
+
+// historyEntry backs the /spotters rolling-window aggregation.
+type historyEntry struct {
+	spotter string
+	at      time.Time
+}
+
+// SpotStore is the mutex-protected, subscribable home for the set of
+// spots currently pushed to the radio. It replaces the old bare spotIds
+// map so the HTTP dashboard can read a consistent snapshot and receive
+// live updates without polling.
+type SpotStore struct {
+	mu      sync.Mutex
+	spots   map[spotKey]spotRecord
+	history []historyEntry
+	subs    map[chan SpotEvent]struct{}
+}
+
+// NewSpotStore returns an empty SpotStore ready for use.
+func NewSpotStore() *SpotStore {
+	return &SpotStore{
+		spots: map[spotKey]spotRecord{},
+		subs:  map[chan SpotEvent]struct{}{},
+	}
+}
+
+// Get returns the current record for key, if any.
+func (s *SpotStore) Get(key spotKey) (spotRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.spots[key]
+	return r, ok
+}
+
+// Set stores (or replaces) the record for key, records spotter activity,
+// and notifies subscribers.
+func (s *SpotStore) Set(key spotKey, rec spotRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.spots[key] = rec
+	s.history = append(s.history, historyEntry{spotter: rec.Spotter, at: time.Now()})
+	s.publishLocked(SpotEvent{Type: "add", Spot: rec})
+}
+
+// Delete removes key, notifying subscribers if it was present.
+func (s *SpotStore) Delete(key spotKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.spots[key]
+	if !ok {
+		return
+	}
+	delete(s.spots, key)
+	s.publishLocked(SpotEvent{Type: "remove", Spot: rec})
+}
+
+// Cleanup drops expired spots and trims spotter history older than
+// historyWindow, notifying subscribers of any removals. onExpire is
+// called for each dropped spot (key and band) so callers that track
+// their own per-band state, like RuleEngine's admission budgets, can
+// stay in sync with spots leaving via normal TTL expiry rather than
+// just via explicit removeSpot calls.
+func (s *SpotStore) Cleanup(historyWindow time.Duration, onExpire func(key spotKey, band string)) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, v := range s.spots {
+		if v.Expires.Before(now) {
+			delete(s.spots, k)
+			s.publishLocked(SpotEvent{Type: "remove", Spot: v})
+			onExpire(k, v.Band)
+		}
+	}
+
+	cutoff := now.Add(-historyWindow)
+	trimmed := s.history[:0]
+	for _, h := range s.history {
+		if h.at.After(cutoff) {
+			trimmed = append(trimmed, h)
+		}
+	}
+	s.history = trimmed
+}
+
+// Snapshot returns a copy of all currently active spots.
+func (s *SpotStore) Snapshot() []spotRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]spotRecord, 0, len(s.spots))
+	for _, r := range s.spots {
+		out = append(out, r)
+	}
+	return out
+}
+
+// SpottersSince aggregates spot counts by spotter callsign since since.
+func (s *SpotStore) SpottersSince(since time.Time) map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := map[string]int{}
+	for _, h := range s.history {
+		if h.at.After(since) {
+			counts[h.spotter]++
+		}
+	}
+	return counts
+}
+
+// BandCounts returns the number of currently active spots per band.
+func (s *SpotStore) BandCounts() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := map[string]int{}
+	for _, r := range s.spots {
+		counts[r.Band]++
+	}
+	return counts
+}
+
+// Subscribe registers a channel that receives future SpotEvents; events
+// that find its buffer full are dropped (see publishLocked).
+// The returned cancel func must be called once the subscriber is done.
+func (s *SpotStore) Subscribe() (chan SpotEvent, func()) {
+	ch := make(chan SpotEvent, 32)
+	s.mu.Lock()
+	s.subs[ch] = struct{}{}
+	s.mu.Unlock()
+
+	cancel := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.subs[ch]; ok {
+			delete(s.subs, ch)
+			close(ch)
+		}
+	}
+	return ch, cancel
+}
+
+// publishLocked notifies subscribers; callers must hold s.mu. A
+// subscriber that isn't keeping up has its event dropped rather than
+// blocking spot processing.
+func (s *SpotStore) publishLocked(ev SpotEvent) {
+	for ch := range s.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
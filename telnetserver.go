@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	log "github.com/rs/zerolog/log"
+)
+
+// passthroughWhitelist lists the read-only commands a rebroadcast
+// client is allowed to pass through to the shared upstream session;
+// anything else is rejected so one LAN client can't do something
+// surprising to the login every other client depends on.
+var passthroughWhitelist = []string{"sh/dx", "show/dx", "sh/wwv", "show/wwv", "sh/qrz", "show/qrz"}
+
+// passthroughInterval rate-limits how often a single client may pass a
+// command through to the shared upstream session.
+const passthroughInterval = 2 * time.Second
+
+func isPassthroughAllowed(cmd string) bool {
+	cmd = strings.ToLower(strings.TrimSpace(cmd))
+	for _, allowed := range passthroughWhitelist {
+		if strings.HasPrefix(cmd, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// serveClusterListener runs the -listen telnet rebroadcast server until
+// the process exits: every connection gets a backfill of active spots
+// followed by the live stream, and may pass a whitelisted, rate-limited
+// set of commands through to upstream.
+func serveClusterListener(addr string, hub *ClusterHub, store *SpotStore, upstream io.Writer) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Error().Err(err).Msg("starting cluster rebroadcast listener")
+		return
+	}
+	defer ln.Close()
+
+	log.Info().Str("addr", addr).Msg("starting cluster rebroadcast listener")
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			log.Error().Err(err).Msg("accepting rebroadcast client")
+			continue
+		}
+		go handleClusterClient(conn, hub, store, upstream)
+	}
+}
+
+func handleClusterClient(conn net.Conn, hub *ClusterHub, store *SpotStore, upstream io.Writer) {
+	defer conn.Close()
+
+	client := hub.Join(store)
+	defer hub.Leave(client)
+
+	go func() {
+		for line := range client.lines {
+			if _, err := fmt.Fprintf(conn, "%s\n", line); err != nil {
+				return
+			}
+		}
+	}()
+
+	var lastCmd time.Time
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		cmd := strings.TrimSpace(scanner.Text())
+		if cmd == "" {
+			continue
+		}
+		if !isPassthroughAllowed(cmd) {
+			fmt.Fprintln(conn, "% command not permitted on shared connection")
+			continue
+		}
+		if time.Since(lastCmd) < passthroughInterval {
+			fmt.Fprintln(conn, "% rate limit exceeded, try again shortly")
+			continue
+		}
+		lastCmd = time.Now()
+		fmt.Fprintf(upstream, "%s\n", cmd)
+	}
+}